@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// animationFormat selects how a multi-frame slide capture is encoded.
+type animationFormat string
+
+const (
+	animationFormatGIF animationFormat = "gif"
+	animationFormatMP4 animationFormat = "mp4"
+)
+
+// encodeAnimation renders frames as an animated GIF or an MP4 (via ffmpeg)
+// sampled at interval, and writes the result to a temp file in dir,
+// returning its absolute path.
+func encodeAnimation(frames []capturedFrame, interval time.Duration, format animationFormat, dir string) (string, error) {
+	if len(frames) < 2 {
+		return "", fmt.Errorf("encodeAnimation: need at least 2 frames, got %d", len(frames))
+	}
+	switch format {
+	case animationFormatMP4:
+		return encodeMP4(frames, interval, dir)
+	default:
+		return encodeGIF(frames, interval, dir)
+	}
+}
+
+// encodeGIF builds an animated GIF using a palette shared by every frame, so
+// the result doesn't flicker from each frame picking its own nearest
+// colors independently.
+func encodeGIF(frames []capturedFrame, interval time.Duration, dir string) (string, error) {
+	pal := buildGlobalPalette(frames)
+
+	delay := int(interval / (10 * time.Millisecond)) // GIF delay units are 1/100s
+	if delay <= 0 {
+		delay = 1
+	}
+
+	g := &gif.GIF{}
+	for _, f := range frames {
+		paletted := image.NewPaletted(f.img.Bounds(), pal)
+		draw.FloydSteinberg.Draw(paletted, f.img.Bounds(), f.img, f.img.Bounds().Min)
+		g.Image = append(g.Image, paletted)
+		g.Delay = append(g.Delay, delay)
+	}
+
+	tmpFile, err := os.CreateTemp(dir, "slide-anim-*.gif")
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+
+	if err := gif.EncodeAll(tmpFile, g); err != nil {
+		return "", fmt.Errorf("encode gif: %w", err)
+	}
+	return filepath.Abs(tmpFile.Name())
+}
+
+// buildGlobalPalette computes a palette shared by all frames in the
+// sequence. Storyline slides are mostly flat-color UI, so an exact palette
+// is used when the frames contain few enough distinct colors; otherwise we
+// fall back to the stdlib's websafe palette rather than spend time on a
+// proper quantizer. Pixels are sampled on a stride to keep this cheap.
+func buildGlobalPalette(frames []capturedFrame) color.Palette {
+	const stride = 4
+	seen := make(map[color.RGBA64]struct{})
+	var pal color.Palette
+
+	for _, f := range frames {
+		b := f.img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y += stride {
+			for x := b.Min.X; x < b.Max.X; x += stride {
+				r, g, bl, a := f.img.At(x, y).RGBA()
+				key := color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(bl), A: uint16(a)}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				pal = append(pal, key)
+				if len(pal) > 256 {
+					return palette.WebSafe
+				}
+			}
+		}
+	}
+	if len(pal) == 0 {
+		return palette.WebSafe
+	}
+	return pal
+}
+
+// encodeMP4 shells out to ffmpeg to mux the frames into an MP4 at the given
+// frame rate. Returns an error if ffmpeg isn't on PATH.
+func encodeMP4(frames []capturedFrame, interval time.Duration, dir string) (string, error) {
+	ffmpegPath, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg not found in PATH: %w", err)
+	}
+
+	frameDir, err := os.MkdirTemp(dir, "slide-anim-frames-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(frameDir)
+
+	for i, f := range frames {
+		framePath := filepath.Join(frameDir, fmt.Sprintf("frame-%04d.png", i))
+		out, err := os.Create(framePath)
+		if err != nil {
+			return "", err
+		}
+		err = png.Encode(out, f.img)
+		out.Close()
+		if err != nil {
+			return "", fmt.Errorf("encode frame %d: %w", i, err)
+		}
+	}
+
+	outFile, err := os.CreateTemp(dir, "slide-anim-*.mp4")
+	if err != nil {
+		return "", err
+	}
+	outPath, err := filepath.Abs(outFile.Name())
+	outFile.Close()
+	if err != nil {
+		return "", err
+	}
+
+	fps := 1.0 / interval.Seconds()
+	cmd := exec.Command(ffmpegPath,
+		"-y",
+		"-framerate", fmt.Sprintf("%f", fps),
+		"-i", filepath.Join(frameDir, "frame-%04d.png"),
+		"-pix_fmt", "yuv420p",
+		outPath,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ffmpeg: %w\n%s", err, out)
+	}
+	return outPath, nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/orisano/pixelmatch"
+)
+
+// stabilityConfig controls how the capture engine decides a slide has
+// finished animating and settled on its final frame.
+type stabilityConfig struct {
+	threshold    float64       // fraction of differing pixels still counted as "changed"
+	settleFrames int           // consecutive stable frames required before we call it settled
+	pollInterval time.Duration // delay between frame samples
+	timeout      time.Duration // hard cap on how long we wait for settling
+}
+
+// capturedFrame is a settled screenshot plus bookkeeping about how unstable
+// the slide was while we waited for it, so callers can log flaky slides.
+type capturedFrame struct {
+	png       []byte
+	img       image.Image
+	diffCount int // pixels that differed between the final two samples
+}
+
+// frameSequence holds every frame sampled for a single slide plus the poster
+// (the last stable frame), which is what gets used when the slide only
+// needed a single static capture.
+type frameSequence struct {
+	frames []capturedFrame
+	poster *capturedFrame
+}
+
+// clickNextJS clicks the Storyline Next button and reports whether a button
+// was actually found and clicked.
+const clickNextJS = `
+	(function() {
+		const btn = document.querySelector('#next') ||
+					document.querySelector('.next-button') ||
+					document.querySelector('div[data-model-id="5hW..."]');
+		if (!btn) return false;
+		btn.click();
+		return true;
+	})()
+`
+
+// captureSettledFrame repeatedly screenshots the page until the fraction of
+// changed pixels between consecutive frames stays below cfg.threshold for
+// cfg.settleFrames samples in a row, or cfg.timeout elapses. This replaces a
+// fixed time.Sleep and correctly rides out Storyline entrance animations
+// instead of guessing how long they take.
+func captureSettledFrame(ctx context.Context, cfg stabilityConfig) (*capturedFrame, error) {
+	deadline := time.Now().Add(cfg.timeout)
+
+	var prevImg image.Image
+	var frame capturedFrame
+	stable := 0
+
+	for {
+		var buf []byte
+		if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
+			return nil, fmt.Errorf("capture screenshot: %w", err)
+		}
+		img, _, err := image.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return nil, fmt.Errorf("decode screenshot: %w", err)
+		}
+
+		if prevImg != nil {
+			diff, frac, err := pixelDiff(prevImg, img)
+			if err != nil {
+				return nil, err
+			}
+			frame.diffCount = diff
+			if frac < cfg.threshold {
+				stable++
+			} else {
+				stable = 0
+			}
+		}
+		frame.png, frame.img = buf, img
+		prevImg = img
+
+		if stable >= cfg.settleFrames || time.Now().After(deadline) {
+			return &frame, nil
+		}
+		time.Sleep(cfg.pollInterval)
+	}
+}
+
+// captureFrameSequence samples up to maxFrames screenshots at interval while
+// a slide's timeline animation plays, stopping early once the frames have
+// been stable for cfg.settleFrames in a row. The final frame is always kept
+// as the poster image, whether or not more than one frame was captured.
+func captureFrameSequence(ctx context.Context, cfg stabilityConfig, maxFrames int, interval time.Duration) (*frameSequence, error) {
+	if maxFrames < 1 {
+		maxFrames = 1
+	}
+
+	seq := &frameSequence{}
+	stable := 0
+
+	for i := 0; i < maxFrames; i++ {
+		var buf []byte
+		if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
+			return nil, fmt.Errorf("capture screenshot: %w", err)
+		}
+		img, _, err := image.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return nil, fmt.Errorf("decode screenshot: %w", err)
+		}
+		frame := capturedFrame{png: buf, img: img}
+
+		if len(seq.frames) > 0 {
+			prev := seq.frames[len(seq.frames)-1]
+			diff, frac, err := pixelDiff(prev.img, img)
+			if err != nil {
+				return nil, err
+			}
+			frame.diffCount = diff
+			if frac < cfg.threshold {
+				stable++
+			} else {
+				stable = 0
+			}
+		}
+		seq.frames = append(seq.frames, frame)
+
+		if stable >= cfg.settleFrames {
+			break
+		}
+		if i < maxFrames-1 {
+			time.Sleep(interval)
+		}
+	}
+
+	seq.poster = &seq.frames[len(seq.frames)-1]
+	return seq, nil
+}
+
+// clickNextAndWait clicks the Storyline Next button and waits for the frame
+// to settle again. If the settled frame after clicking matches the frame we
+// had before clicking (within threshold), the click was a no-op and we treat
+// it as end-of-course. This replaces the previous .disabled/aria-disabled
+// sniffing, which Storyline themes don't apply consistently.
+func clickNextAndWait(ctx context.Context, cfg stabilityConfig, before *capturedFrame) (after *capturedFrame, noop bool, err error) {
+	var clicked bool
+	if err := chromedp.Run(ctx, chromedp.Evaluate(clickNextJS, &clicked)); err != nil {
+		return nil, false, fmt.Errorf("click next: %w", err)
+	}
+	if !clicked {
+		return before, true, nil
+	}
+
+	after, err = captureSettledFrame(ctx, cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	diff, frac, err := pixelDiff(before.img, after.img)
+	if err != nil {
+		return nil, false, err
+	}
+	if frac < cfg.threshold {
+		after.diffCount = diff
+		return after, true, nil
+	}
+	return after, false, nil
+}
+
+// pixelDiff returns the number of differing pixels between a and b and their
+// fraction of the total, using pixelmatch's YIQ color-distance comparison. A
+// dimension mismatch is treated as a fully-changed frame.
+func pixelDiff(a, b image.Image) (count int, fraction float64, err error) {
+	bounds := a.Bounds()
+	if b.Bounds() != bounds {
+		return bounds.Dx() * bounds.Dy(), 1, nil
+	}
+	total := bounds.Dx() * bounds.Dy()
+	if total == 0 {
+		return 0, 0, nil
+	}
+	n, err := pixelmatch.MatchPixel(toRGBA(a), toRGBA(b), pixelmatch.Threshold(0.1))
+	if err != nil {
+		return 0, 0, fmt.Errorf("pixelmatch: %w", err)
+	}
+	return n, float64(n) / float64(total), nil
+}
+
+// toRGBA returns img as *image.RGBA, converting if necessary.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, img.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
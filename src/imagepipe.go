@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+
+	"golang.org/x/image/draw"
+)
+
+// processedImage is the result of running a decoded screenshot through the
+// resize and format-selection pipeline: encoded bytes ready to write once,
+// plus the format and final dimensions for logging.
+type processedImage struct {
+	bytes  []byte
+	format string // "jpeg", "png", or "webp"
+	bounds image.Rectangle
+}
+
+// processImage optionally downscales img to maxWidth (0 disables resizing)
+// and encodes it per formatFlag ("auto", "jpeg", "png", or "webp"). In auto
+// mode, frames with few enough distinct colors (flat UI chrome, which JPEG's
+// block compression handles poorly) are encoded as PNG; everything else is
+// encoded as JPEG.
+func processImage(img image.Image, maxWidth, jpegQuality int, formatFlag string) (*processedImage, error) {
+	if maxWidth > 0 && img.Bounds().Dx() > maxWidth {
+		img = downscale(img, maxWidth)
+	}
+
+	format := formatFlag
+	if format == "auto" {
+		if isFlatColor(img) {
+			format = "png"
+		} else {
+			format = "jpeg"
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+	case "webp":
+		encoded, err := encodeWebP(img)
+		if err != nil {
+			return nil, fmt.Errorf("encode webp: %w", err)
+		}
+		buf.Write(encoded)
+	default:
+		format = "jpeg"
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+	}
+
+	return &processedImage{bytes: buf.Bytes(), format: format, bounds: img.Bounds()}, nil
+}
+
+// downscale resizes img to maxWidth using a high-quality Catmull-Rom
+// resampler, preserving aspect ratio.
+func downscale(img image.Image, maxWidth int) image.Image {
+	srcBounds := img.Bounds()
+	ratio := float64(maxWidth) / float64(srcBounds.Dx())
+	maxHeight := int(float64(srcBounds.Dy()) * ratio)
+
+	dst := image.NewRGBA(image.Rect(0, 0, maxWidth, maxHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, srcBounds, draw.Over, nil)
+	return dst
+}
+
+// isFlatColor is a cheap heuristic for "this frame is mostly flat UI chrome,
+// not a photo": it samples pixels on a stride and counts distinct colors,
+// returning true when the ratio of distinct colors to samples is small.
+func isFlatColor(img image.Image) bool {
+	const stride = 8
+	const uniqueColorRatioThreshold = 0.02
+
+	b := img.Bounds()
+	seen := make(map[color.RGBA64]struct{})
+	sampled := 0
+	for y := b.Min.Y; y < b.Max.Y; y += stride {
+		for x := b.Min.X; x < b.Max.X; x += stride {
+			r, g, bl, a := img.At(x, y).RGBA()
+			seen[color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(bl), A: uint16(a)}] = struct{}{}
+			sampled++
+		}
+	}
+	if sampled == 0 {
+		return false
+	}
+	return float64(len(seen))/float64(sampled) < uniqueColorRatioThreshold
+}
+
+// encodeWebP shells out to cwebp, since neither the Go standard library nor
+// golang.org/x/image can encode WEBP (only decode it).
+func encodeWebP(img image.Image) ([]byte, error) {
+	cwebpPath, err := exec.LookPath("cwebp")
+	if err != nil {
+		return nil, fmt.Errorf("cwebp not found in PATH: %w", err)
+	}
+
+	srcFile, err := os.CreateTemp("", "webp-src-*.png")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(srcFile.Name())
+	if err := png.Encode(srcFile, img); err != nil {
+		srcFile.Close()
+		return nil, err
+	}
+	srcFile.Close()
+
+	dstPath := srcFile.Name() + ".webp"
+	defer os.Remove(dstPath)
+
+	cmd := exec.Command(cwebpPath, "-quiet", srcFile.Name(), "-o", dstPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cwebp: %w\n%s", err, out)
+	}
+
+	return os.ReadFile(dstPath)
+}
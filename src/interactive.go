@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+// clickPrevJS clicks the Storyline Previous button and reports whether a
+// button was actually found and clicked.
+const clickPrevJS = `
+	(function() {
+		const btn = document.querySelector('#prev') ||
+					document.querySelector('.prev-button') ||
+					document.querySelector('div[data-model-id="4gW..."]');
+		if (!btn) return false;
+		btn.click();
+		return true;
+	})()
+`
+
+const extractTextJS = `document.body.innerText`
+
+// interactiveController serves the human-in-the-loop capture UI. It wraps
+// the same chromedp context and output sinks used by batch mode so Capture
+// requests go through the identical addSlideToSinks path.
+type interactiveController struct {
+	ctx   context.Context
+	sinks []SlideSink
+	cfg   stabilityConfig
+
+	mu        sync.Mutex
+	slideNum  int
+	lastNotes string
+	done      chan struct{}
+	finish    sync.Once
+}
+
+func newInteractiveController(ctx context.Context, sinks []SlideSink, cfg stabilityConfig) *interactiveController {
+	return &interactiveController{ctx: ctx, sinks: sinks, cfg: cfg, done: make(chan struct{})}
+}
+
+// registerRoutes wires the UI and its API onto mux under /ui. The caller is
+// expected to also mount the content file server on the same mux so the
+// Storyline course itself stays reachable at its usual paths.
+func (ic *interactiveController) registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/ui", ic.handlePage)
+	mux.HandleFunc("/ui/frame", ic.handleFrame)
+	mux.HandleFunc("/ui/notes", ic.handleNotes)
+	mux.HandleFunc("/ui/next", ic.handleNext)
+	mux.HandleFunc("/ui/prev", ic.handlePrev)
+	mux.HandleFunc("/ui/capture", ic.handleCapture)
+	mux.HandleFunc("/ui/skip", ic.handleNext) // Skip just advances without capturing
+	mux.HandleFunc("/ui/retake", ic.handleRetake)
+	mux.HandleFunc("/ui/finish", ic.handleFinish)
+}
+
+// wait blocks until the operator clicks "Finish and Save PPTX".
+func (ic *interactiveController) wait() {
+	<-ic.done
+}
+
+func (ic *interactiveController) handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, interactiveUIHTML)
+}
+
+// handleFrame returns a single live JPEG snapshot of the page. The UI
+// polls this on an interval rather than opening a streaming connection,
+// which keeps the server side of interactive mode as simple as batch mode.
+func (ic *interactiveController) handleFrame(w http.ResponseWriter, r *http.Request) {
+	var buf []byte
+	if err := chromedp.Run(ic.ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
+		http.Error(w, fmt.Sprintf("capture screenshot: %v", err), http.StatusInternalServerError)
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decode screenshot: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := jpeg.Encode(w, img, &jpeg.Options{Quality: 70}); err != nil {
+		log.Printf("Error encoding live frame: %v", err)
+	}
+}
+
+// handleNotes returns the page's current innerText, used to seed the
+// editable notes field whenever the operator (re)loads a slide.
+func (ic *interactiveController) handleNotes(w http.ResponseWriter, r *http.Request) {
+	var text string
+	if err := chromedp.Run(ic.ctx, chromedp.Evaluate(extractTextJS, &text)); err != nil {
+		http.Error(w, fmt.Sprintf("extract text: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"notes": text})
+}
+
+func (ic *interactiveController) handleNext(w http.ResponseWriter, r *http.Request) {
+	var clicked bool
+	if err := chromedp.Run(ic.ctx, chromedp.Evaluate(clickNextJS, &clicked)); err != nil {
+		http.Error(w, fmt.Sprintf("click next: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]bool{"clicked": clicked})
+}
+
+func (ic *interactiveController) handlePrev(w http.ResponseWriter, r *http.Request) {
+	var clicked bool
+	if err := chromedp.Run(ic.ctx, chromedp.Evaluate(clickPrevJS, &clicked)); err != nil {
+		http.Error(w, fmt.Sprintf("click prev: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]bool{"clicked": clicked})
+}
+
+// handleCapture waits for the current slide to settle and adds it to every
+// configured sink via the same addSlideToSinks path batch mode uses, so a
+// hand-curated deck gets identical image processing and notes handling.
+func (ic *interactiveController) handleCapture(w http.ResponseWriter, r *http.Request) {
+	notes := r.URL.Query().Get("notes")
+
+	frame, err := captureSettledFrame(ic.ctx, ic.cfg)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("capture slide: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	seq := &frameSequence{frames: []capturedFrame{*frame}, poster: frame}
+	if err := addSlideToSinks(ic.sinks, seq, notes); err != nil {
+		http.Error(w, fmt.Sprintf("add slide: %v", err), http.StatusInternalServerError)
+		return
+	}
+	ic.slideNum++
+	ic.lastNotes = notes
+	writeJSON(w, map[string]int{"slide": ic.slideNum})
+}
+
+// handleRetake re-captures the current slide. Neither gooxml nor the PDF
+// sink expose a way to remove an already-added slide, so this appends a
+// fresh capture and logs a reminder that the previous one needs deleting by
+// hand in the output file(s).
+func (ic *interactiveController) handleRetake(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Retake requested: the previous capture of this slide stays in every output " +
+		"(no sink supports slide removal) and will need deleting manually")
+	ic.handleCapture(w, r)
+}
+
+func (ic *interactiveController) handleFinish(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]int{"slides": ic.slideNum})
+	// A double-submit (double-click, a retried fetch) must not panic on a
+	// second close of an already-closed channel.
+	ic.finish.Do(func() { close(ic.done) })
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
+
+// interactiveUIHTML is the operator-facing page: a polled live view of the
+// headless browser, navigation/capture buttons, and an editable notes field.
+const interactiveUIHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Storyline Capture</title>
+<style>
+  body { font-family: sans-serif; margin: 1em; }
+  #frame { width: 1280px; max-width: 100%; border: 1px solid #ccc; }
+  #notes { width: 1280px; max-width: 100%; height: 4em; }
+  button { padding: 0.5em 1em; margin-right: 0.5em; }
+  #status { color: #555; }
+</style>
+</head>
+<body>
+  <h1>Storyline Capture</h1>
+  <img id="frame" src="/ui/frame">
+  <div>
+    <button onclick="nav('prev')">Prev</button>
+    <button onclick="nav('next')">Next</button>
+    <button onclick="capture()">Capture</button>
+    <button onclick="nav('skip')">Skip</button>
+    <button onclick="retake()">Retake</button>
+    <button onclick="finish()">Finish and Save PPTX</button>
+  </div>
+  <p>Notes (editable before Capture):</p>
+  <textarea id="notes"></textarea>
+  <p id="status"></p>
+<script>
+function refreshFrame() {
+  document.getElementById('frame').src = '/ui/frame?t=' + Date.now();
+}
+function refreshNotes() {
+  fetch('/ui/notes').then(r => r.json()).then(d => {
+    document.getElementById('notes').value = d.notes;
+  });
+}
+function nav(which) {
+  fetch('/ui/' + which, {method: 'POST'}).then(() => {
+    setTimeout(() => { refreshFrame(); refreshNotes(); }, 500);
+  });
+}
+function capture() {
+  const notes = encodeURIComponent(document.getElementById('notes').value);
+  fetch('/ui/capture?notes=' + notes, {method: 'POST'}).then(r => r.json()).then(d => {
+    document.getElementById('status').textContent = 'Captured slide ' + d.slide;
+  });
+}
+function retake() {
+  const notes = encodeURIComponent(document.getElementById('notes').value);
+  fetch('/ui/retake?notes=' + notes, {method: 'POST'}).then(r => r.json()).then(d => {
+    document.getElementById('status').textContent = 'Re-captured as slide ' + d.slide;
+  });
+}
+function finish() {
+  fetch('/ui/finish', {method: 'POST'}).then(r => r.json()).then(d => {
+    document.getElementById('status').textContent = 'Saved ' + d.slides + ' slide(s). You may close this tab.';
+  });
+}
+setInterval(refreshFrame, 1000);
+refreshNotes();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,295 @@
+// Package iso extracts ISO 9660 images while preferring the Rock Ridge and
+// Joliet naming extensions over plain ISO 9660's truncated 8.3 uppercase
+// names. Storyline exports rely on long asset filenames
+// (story_content/6zH8.../long-name.js) that plain ISO 9660 mangles beyond
+// recognition, which breaks the local HTTP server's relative asset paths.
+package iso
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	sectorSize  = 2048
+	systemArea  = 16 // sectors 0-15 are reserved for the boot/system area
+	dirFlagsDir = 1 << 1
+)
+
+// volume describes the directory tree this package will actually walk:
+// wherever its naming extension lives, plus whether Rock Ridge per-entry
+// names (and symlinks) need to be consulted while walking it.
+type volume struct {
+	rootExtent uint32
+	rootSize   uint32
+	joliet     bool
+	rockRidge  bool
+}
+
+// Extract extracts the ISO 9660 image at isoPath into dest, preserving the
+// original streaming io.Copy behavior for file data. Naming is resolved in
+// this order: Rock Ridge (SUSP NM records) first, then a Joliet
+// Supplementary Volume Descriptor, then plain ISO 9660 if the disc carries
+// neither extension. Rock Ridge wins over Joliet on hybrid discs since it
+// also recovers case and POSIX attributes that Joliet's UCS-2 names don't.
+func Extract(isoPath, dest string) error {
+	f, err := os.Open(isoPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	vol, err := readVolume(f)
+	if err != nil {
+		return err
+	}
+	return extractDir(f, vol, vol.rootExtent, vol.rootSize, dest)
+}
+
+// readVolume scans the Volume Descriptor Set for the Primary Volume
+// Descriptor (required) and a Joliet Supplementary Volume Descriptor
+// (optional), then checks the primary root directory's "." entry for a
+// Rock Ridge SUSP signature.
+func readVolume(r io.ReaderAt) (*volume, error) {
+	var primaryExtent, primarySize uint32
+	var havePrimary bool
+	var jolietExtent, jolietSize uint32
+	var haveJoliet bool
+
+	sector := make([]byte, sectorSize)
+	for i := systemArea; ; i++ {
+		if _, err := r.ReadAt(sector, int64(i)*sectorSize); err != nil {
+			return nil, fmt.Errorf("iso: reading volume descriptor %d: %w", i, err)
+		}
+		if string(sector[1:6]) != "CD001" {
+			return nil, fmt.Errorf("iso: bad volume descriptor signature at sector %d", i)
+		}
+
+		switch sector[0] {
+		case 1: // Primary Volume Descriptor
+			root, err := parseDirEntry(sector[156 : 156+34])
+			if err != nil {
+				return nil, fmt.Errorf("iso: primary root directory record: %w", err)
+			}
+			primaryExtent, primarySize = root.extent, root.dataLen
+			havePrimary = true
+		case 2: // Supplementary Volume Descriptor
+			if isJolietEscape(sector[88:120]) {
+				root, err := parseDirEntry(sector[156 : 156+34])
+				if err != nil {
+					return nil, fmt.Errorf("iso: joliet root directory record: %w", err)
+				}
+				jolietExtent, jolietSize = root.extent, root.dataLen
+				haveJoliet = true
+			}
+		case 255: // Volume Descriptor Set Terminator
+			if !havePrimary {
+				return nil, fmt.Errorf("iso: no primary volume descriptor found")
+			}
+			rootEntries, err := readDirEntries(r, primaryExtent, primarySize)
+			if err != nil {
+				return nil, fmt.Errorf("iso: reading root directory: %w", err)
+			}
+			rockRidge := len(rootEntries) > 0 && hasRockRidge(rootEntries[0].systemUse)
+
+			vol := &volume{rockRidge: rockRidge}
+			switch {
+			case rockRidge:
+				vol.rootExtent, vol.rootSize = primaryExtent, primarySize
+			case haveJoliet:
+				vol.rootExtent, vol.rootSize, vol.joliet = jolietExtent, jolietSize, true
+			default:
+				vol.rootExtent, vol.rootSize = primaryExtent, primarySize
+			}
+			return vol, nil
+		}
+	}
+}
+
+// isJolietEscape reports whether esc opens with one of the three Joliet
+// UCS-2 escape sequences (level 1, 2, or 3).
+func isJolietEscape(esc []byte) bool {
+	for _, seq := range [][]byte{{0x25, 0x2F, 0x40}, {0x25, 0x2F, 0x43}, {0x25, 0x2F, 0x45}} {
+		if bytes.HasPrefix(esc, seq) {
+			return true
+		}
+	}
+	return false
+}
+
+// dirEntry is one parsed ISO 9660 directory record.
+type dirEntry struct {
+	recLen    byte
+	extent    uint32
+	dataLen   uint32
+	flags     byte
+	id        []byte
+	systemUse []byte
+}
+
+// parseDirEntry parses a single directory record starting at b[0]. A
+// recLen of 0 marks padding to the end of a sector, which the caller
+// recognizes and stops on.
+func parseDirEntry(b []byte) (dirEntry, error) {
+	if len(b) < 1 {
+		return dirEntry{}, fmt.Errorf("iso: empty directory record")
+	}
+	recLen := b[0]
+	if recLen == 0 {
+		return dirEntry{}, nil
+	}
+	if int(recLen) > len(b) || recLen < 34 {
+		return dirEntry{}, fmt.Errorf("iso: directory record of length %d overruns its sector", recLen)
+	}
+
+	extent := binary.LittleEndian.Uint32(b[2:6])
+	dataLen := binary.LittleEndian.Uint32(b[10:14])
+	flags := b[25]
+
+	idLen := int(b[32])
+	idStart := 33
+	idEnd := idStart + idLen
+	if idEnd > int(recLen) {
+		return dirEntry{}, fmt.Errorf("iso: directory record identifier overruns record")
+	}
+	id := b[idStart:idEnd]
+
+	suStart := idEnd
+	if idLen%2 == 0 { // padding byte present whenever the identifier length is even
+		suStart++
+	}
+	var systemUse []byte
+	if suStart < int(recLen) {
+		systemUse = b[suStart:recLen]
+	}
+
+	return dirEntry{recLen: recLen, extent: extent, dataLen: dataLen, flags: flags, id: id, systemUse: systemUse}, nil
+}
+
+// readDirEntries reads and parses every directory record in the extent
+// starting at extent spanning size bytes. Records never span a sector
+// boundary, so each 2048-byte sector is walked independently.
+func readDirEntries(r io.ReaderAt, extent, size uint32) ([]dirEntry, error) {
+	buf := make([]byte, size)
+	if _, err := r.ReadAt(buf, int64(extent)*sectorSize); err != nil {
+		return nil, err
+	}
+
+	var entries []dirEntry
+	for sectorOff := 0; sectorOff < len(buf); sectorOff += sectorSize {
+		end := sectorOff + sectorSize
+		if end > len(buf) {
+			end = len(buf)
+		}
+		sector := buf[sectorOff:end]
+
+		for pos := 0; pos < len(sector); {
+			e, err := parseDirEntry(sector[pos:])
+			if err != nil {
+				return nil, err
+			}
+			if e.recLen == 0 {
+				break
+			}
+			entries = append(entries, e)
+			pos += int(e.recLen)
+		}
+	}
+	return entries, nil
+}
+
+// isDotEntry reports whether e is the "." or ".." self-reference record
+// every ISO 9660 directory starts with.
+func isDotEntry(e dirEntry) bool {
+	return len(e.id) == 1 && (e.id[0] == 0 || e.id[0] == 1)
+}
+
+// entryName recovers e's filename under the naming scheme vol selected:
+// Rock Ridge NM first, then Joliet UCS-2, then plain ISO 9660 with its
+// ";<version>" suffix and trailing dot (for extension-less names) removed.
+func entryName(e dirEntry, vol *volume) (name string, symlink bool) {
+	if vol.rockRidge {
+		if name, symlink := rockRidgeName(e.systemUse); name != "" {
+			return name, symlink
+		}
+	}
+	if vol.joliet {
+		return decodeUCS2BE(e.id), false
+	}
+	name = string(e.id)
+	if i := bytes.IndexByte(e.id, ';'); i >= 0 {
+		name = string(e.id[:i])
+	}
+	if len(name) > 0 && name[len(name)-1] == '.' {
+		name = name[:len(name)-1]
+	}
+	return name, false
+}
+
+func decodeUCS2BE(b []byte) string {
+	var sb []rune
+	for i := 0; i+1 < len(b); i += 2 {
+		sb = append(sb, rune(binary.BigEndian.Uint16(b[i:i+2])))
+	}
+	return string(sb)
+}
+
+// extractDir walks one directory extent, recursing into subdirectories and
+// streaming file data straight to disk.
+func extractDir(r io.ReaderAt, vol *volume, extent, size uint32, dest string) error {
+	entries, err := readDirEntries(r, extent, size)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if isDotEntry(e) {
+			continue
+		}
+		name, symlink := entryName(e, vol)
+		if name == "" {
+			continue
+		}
+		targetPath := filepath.Join(dest, name)
+
+		switch {
+		case symlink:
+			log.Printf("iso: skipping Rock Ridge symlink entry %q (not supported)", name)
+		case e.flags&dirFlagsDir != 0:
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return err
+			}
+			if err := extractDir(r, vol, e.extent, e.dataLen, targetPath); err != nil {
+				return err
+			}
+		default:
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+				return err
+			}
+			if err := extractFile(r, e, targetPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// extractFile streams e's data straight from the backing image to
+// targetPath via io.Copy, the same streaming behavior the previous
+// hooklift-based extractor used.
+func extractFile(r io.ReaderAt, e dirEntry, targetPath string) error {
+	out, err := os.Create(targetPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	sr := io.NewSectionReader(r, int64(e.extent)*sectorSize, int64(e.dataLen))
+	_, err = io.Copy(out, sr)
+	return err
+}
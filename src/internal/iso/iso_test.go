@@ -0,0 +1,234 @@
+package iso
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The fixtures below hand-assemble the smallest ISO 9660 images this
+// package's parser needs to exercise: a Primary Volume Descriptor, an
+// optional Joliet Supplementary Volume Descriptor, a Volume Descriptor Set
+// Terminator, one or two root directory extents, and a single file. They
+// aren't standards-complete discs (dates, most flags, and the path table
+// are left zeroed), only enough of one for this parser to walk correctly.
+
+const fixtureFileData = "hello world\n"
+
+type fixtureOpts struct {
+	joliet, rockRidge  bool
+	jolietName, rrName string
+	plainName          string
+}
+
+// buildFixture assembles an in-memory ISO image per opts and returns it
+// alongside the sector layout it used.
+func buildFixture(opts fixtureOpts) []byte {
+	sector := systemArea
+	pvdSector := sector
+	sector++
+	var svdSector int
+	if opts.joliet {
+		svdSector = sector
+		sector++
+	}
+	termSector := sector
+	sector++
+	primaryRootSector := sector
+	sector++
+	var jolietRootSector int
+	if opts.joliet {
+		jolietRootSector = sector
+		sector++
+	}
+	fileDataSector := sector
+	sector++
+
+	buf := make([]byte, sector*sectorSize)
+
+	writeDescriptorHeader(buf, pvdSector, 1)
+	writeRootRecordField(buf, pvdSector, uint32(primaryRootSector))
+
+	if opts.joliet {
+		writeDescriptorHeader(buf, svdSector, 2)
+		copy(buf[svdSector*sectorSize+88:], []byte{0x25, 0x2F, 0x45}) // Joliet level 3 escape
+		writeRootRecordField(buf, svdSector, uint32(jolietRootSector))
+	}
+
+	writeDescriptorHeader(buf, termSector, 255)
+
+	var dotSystemUse []byte
+	if opts.rockRidge {
+		dotSystemUse = []byte{'S', 'P', 7, 1, 0xBE, 0xEF, 0}
+	}
+	var fileSystemUse []byte
+	if opts.rockRidge {
+		fileSystemUse = nmEntry(opts.rrName)
+	}
+	primaryRoot := buildDirSector([]dirRecordSpec{
+		{id: []byte{0x00}, extent: uint32(primaryRootSector), size: sectorSize, dir: true, systemUse: dotSystemUse},
+		{id: []byte{0x01}, extent: uint32(primaryRootSector), size: sectorSize, dir: true},
+		{id: []byte(opts.plainName), extent: uint32(fileDataSector), size: uint32(len(fixtureFileData)), systemUse: fileSystemUse},
+	})
+	copy(buf[primaryRootSector*sectorSize:], primaryRoot)
+
+	if opts.joliet {
+		jolietRoot := buildDirSector([]dirRecordSpec{
+			{id: []byte{0x00}, extent: uint32(jolietRootSector), size: sectorSize, dir: true},
+			{id: []byte{0x01}, extent: uint32(jolietRootSector), size: sectorSize, dir: true},
+			{id: encodeUCS2BE(opts.jolietName), extent: uint32(fileDataSector), size: uint32(len(fixtureFileData))},
+		})
+		copy(buf[jolietRootSector*sectorSize:], jolietRoot)
+	}
+
+	copy(buf[fileDataSector*sectorSize:], fixtureFileData)
+
+	return buf
+}
+
+func writeDescriptorHeader(buf []byte, sector int, descType byte) {
+	off := sector * sectorSize
+	buf[off] = descType
+	copy(buf[off+1:off+6], "CD001")
+	buf[off+6] = 1
+}
+
+func writeRootRecordField(buf []byte, descriptorSector int, rootExtent uint32) {
+	rec := writeDirRecord([]byte{0x00}, rootExtent, sectorSize, dirFlagsDir, nil)
+	copy(buf[descriptorSector*sectorSize+156:], rec)
+}
+
+type dirRecordSpec struct {
+	id        []byte
+	extent    uint32
+	size      uint32
+	dir       bool
+	systemUse []byte
+}
+
+func buildDirSector(specs []dirRecordSpec) []byte {
+	var out []byte
+	for _, s := range specs {
+		var flags byte
+		if s.dir {
+			flags = dirFlagsDir
+		}
+		out = append(out, writeDirRecord(s.id, s.extent, s.size, flags, s.systemUse)...)
+	}
+	return out
+}
+
+// writeDirRecord builds a single ISO 9660 directory record, mirroring the
+// layout parseDirEntry reads back.
+func writeDirRecord(id []byte, extent, size uint32, flags byte, systemUse []byte) []byte {
+	idLen := len(id)
+	pos := 33 + idLen
+	if idLen%2 == 0 {
+		pos++ // padding byte required whenever the identifier length is even
+	}
+	recLen := pos + len(systemUse)
+	if recLen%2 != 0 {
+		recLen++ // directory records always end on an even boundary
+	}
+
+	buf := make([]byte, recLen)
+	buf[0] = byte(recLen)
+	binary.LittleEndian.PutUint32(buf[2:6], extent)
+	binary.BigEndian.PutUint32(buf[6:10], extent)
+	binary.LittleEndian.PutUint32(buf[10:14], size)
+	binary.BigEndian.PutUint32(buf[14:18], size)
+	buf[25] = flags
+	binary.LittleEndian.PutUint16(buf[28:30], 1)
+	binary.BigEndian.PutUint16(buf[30:32], 1)
+	buf[32] = byte(idLen)
+	copy(buf[33:33+idLen], id)
+	copy(buf[pos:], systemUse)
+	return buf
+}
+
+func nmEntry(name string) []byte {
+	nm := make([]byte, 5+len(name))
+	copy(nm[0:2], "NM")
+	nm[2] = byte(5 + len(name))
+	nm[3] = 1
+	nm[4] = 0 // flags: not a continuation, not "." or ".."
+	copy(nm[5:], name)
+	return nm
+}
+
+func encodeUCS2BE(s string) []byte {
+	out := make([]byte, 0, len(s)*2)
+	for _, r := range s {
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(r))
+		out = append(out, b[:]...)
+	}
+	return out
+}
+
+func extractFixture(t *testing.T, opts fixtureOpts) string {
+	t.Helper()
+	data := buildFixture(opts)
+
+	dir := t.TempDir()
+	isoPath := filepath.Join(dir, "fixture.iso")
+	if err := os.WriteFile(isoPath, data, 0644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	dest := filepath.Join(dir, "out")
+	if err := Extract(isoPath, dest); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	return dest
+}
+
+func assertOnlyFile(t *testing.T, dest, wantName string) {
+	t.Helper()
+	path := filepath.Join(dest, wantName)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected extracted file %q: %v", path, err)
+	}
+	if string(got) != fixtureFileData {
+		t.Errorf("file contents = %q, want %q", got, fixtureFileData)
+	}
+}
+
+func TestExtractPlain(t *testing.T) {
+	dest := extractFixture(t, fixtureOpts{plainName: "HELLO.TXT;1"})
+	assertOnlyFile(t, dest, "HELLO.TXT")
+}
+
+func TestExtractJolietOnly(t *testing.T) {
+	dest := extractFixture(t, fixtureOpts{
+		joliet:     true,
+		jolietName: "hello-world.txt",
+		plainName:  "HELLO.TXT;1",
+	})
+	assertOnlyFile(t, dest, "hello-world.txt")
+}
+
+func TestExtractRockRidgeOnly(t *testing.T) {
+	dest := extractFixture(t, fixtureOpts{
+		rockRidge: true,
+		rrName:    "hello-world-rr.txt",
+		plainName: "HELLO.TXT;1",
+	})
+	assertOnlyFile(t, dest, "hello-world-rr.txt")
+}
+
+// TestExtractHybrid covers a disc carrying both extensions: Rock Ridge
+// should win, since it also recovers case and POSIX attributes Joliet's
+// UCS-2 names don't.
+func TestExtractHybrid(t *testing.T) {
+	dest := extractFixture(t, fixtureOpts{
+		joliet:     true,
+		jolietName: "joliet-name.txt",
+		rockRidge:  true,
+		rrName:     "rockridge-name.txt",
+		plainName:  "HELLO.TXT;1",
+	})
+	assertOnlyFile(t, dest, "rockridge-name.txt")
+}
@@ -0,0 +1,67 @@
+package iso
+
+import "bytes"
+
+// suspEntries walks a directory record's System Use Area, which SUSP lays
+// out as a sequence of [2-byte signature][1-byte length][1-byte
+// version][length-4 bytes of data] entries, and groups the data by
+// signature. Continuation entries ("CE", pointing at further System Use
+// data in another sector) aren't followed; this only reads what fits
+// inline, which covers every field this package uses (SP, ER, NM, SL, PX).
+func suspEntries(b []byte) map[string][][]byte {
+	entries := map[string][][]byte{}
+	for pos := 0; pos+4 <= len(b); {
+		sig := string(b[pos : pos+2])
+		length := int(b[pos+2])
+		if length < 4 || pos+length > len(b) {
+			break
+		}
+		entries[sig] = append(entries[sig], b[pos+4:pos+length])
+		pos += length
+	}
+	return entries
+}
+
+// hasRockRidge reports whether a root directory's "." entry carries a Rock
+// Ridge SUSP signature: either the "SP" indicator present only on that
+// entry (magic bytes 0xBE 0xEF), an "RR" entry, or an "ER" extension
+// reference naming the Rock Ridge/POSIX extension.
+func hasRockRidge(rootSystemUse []byte) bool {
+	entries := suspEntries(rootSystemUse)
+	if sp, ok := entries["SP"]; ok && len(sp) > 0 && len(sp[0]) >= 2 && sp[0][0] == 0xBE && sp[0][1] == 0xEF {
+		return true
+	}
+	if _, ok := entries["RR"]; ok {
+		return true
+	}
+	for _, er := range entries["ER"] {
+		if bytes.Contains(er, []byte("RRIP")) || bytes.Contains(er, []byte("IEEE_P1282")) {
+			return true
+		}
+	}
+	return false
+}
+
+// rockRidgeName reassembles a directory record's Rock Ridge name from its
+// "NM" entries (a name that doesn't fit in one entry is split across
+// several, each but the last flagged NM_CONTINUE) and reports whether an
+// "SL" entry marks the record as a symlink.
+func rockRidgeName(systemUse []byte) (name string, symlink bool) {
+	entries := suspEntries(systemUse)
+
+	var buf bytes.Buffer
+	for _, nm := range entries["NM"] {
+		if len(nm) < 1 {
+			continue
+		}
+		const nmContinue = 1 << 0
+		flags := nm[0]
+		buf.Write(nm[1:])
+		if flags&nmContinue == 0 {
+			break
+		}
+	}
+
+	_, symlink = entries["SL"]
+	return buf.String(), symlink
+}
@@ -0,0 +1,272 @@
+// Package screentest provides golden-image regression testing for the
+// Storyline-to-PPTX converter. A script file drives the converter's own
+// extract-serve-chromedp pipeline up to a given slide, captures a
+// screenshot, and compares it against a checked-in golden PNG using the
+// same pixelmatch-based comparator used for slide-advancement detection.
+package screentest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/orisano/pixelmatch"
+
+	"rafaclearn/internal/iso"
+)
+
+// update overwrites golden images with freshly captured screenshots instead
+// of comparing against them: go test ./internal/screentest/... -update
+var update = flag.Bool("update", false, "overwrite golden images instead of comparing against them")
+
+// clickNextJS mirrors the converter's own Next-button click script.
+const clickNextJS = `
+	(function() {
+		const btn = document.querySelector('#next') ||
+					document.querySelector('.next-button') ||
+					document.querySelector('div[data-model-id="5hW..."]');
+		if (!btn) return false;
+		btn.click();
+		return true;
+	})()
+`
+
+// entry is one line of a screentest script: the ISO to drive, which
+// (1-indexed) slide to capture, the golden PNG to compare against, and the
+// fraction of differing pixels tolerated before it's a failure.
+type entry struct {
+	lineNum    int
+	isoPath    string
+	slideIndex int
+	goldenPath string
+	tolerance  float64
+}
+
+// Check runs every entry in the script at scriptPath and reports all
+// mismatches as a single combined error. Each entry extracts its ISO,
+// serves it locally, drives chromedp to the given slide, and compares the
+// resulting screenshot against the golden PNG. On mismatch a side-by-side
+// diff image (captured | golden | red diff mask) is written next to the
+// golden, suffixed "-diff.png". Run with -update to overwrite goldens with
+// the freshly captured screenshots instead of comparing against them.
+func Check(scriptPath string) error {
+	entries, err := parseScript(scriptPath)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, e := range entries {
+		if err := e.check(); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", scriptPath, e.lineNum, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseScript reads a line-oriented script file. Each non-blank,
+// non-comment ("#") line has four whitespace-separated fields: ISO path,
+// slide index, golden PNG path, tolerance. Relative paths are resolved
+// against the script file's own directory.
+func parseScript(scriptPath string) ([]entry, error) {
+	data, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("read script: %w", err)
+	}
+	dir := filepath.Dir(scriptPath)
+
+	var entries []entry
+	for i, line := range strings.Split(string(data), "\n") {
+		lineNum := i + 1
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%s:%d: want 4 fields (iso, slide, golden, tolerance), got %d", scriptPath, lineNum, len(fields))
+		}
+		slideIndex, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid slide index %q: %w", scriptPath, lineNum, fields[1], err)
+		}
+		tolerance, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid tolerance %q: %w", scriptPath, lineNum, fields[3], err)
+		}
+		entries = append(entries, entry{
+			lineNum:    lineNum,
+			isoPath:    resolvePath(dir, fields[0]),
+			slideIndex: slideIndex,
+			goldenPath: resolvePath(dir, fields[2]),
+			tolerance:  tolerance,
+		})
+	}
+	return entries, nil
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// check drives e's ISO to its target slide, captures a screenshot, and
+// compares it against (or, with -update, overwrites) the golden.
+func (e entry) check() error {
+	tempDir, err := os.MkdirTemp("", "screentest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := iso.Extract(e.isoPath, tempDir); err != nil {
+		return fmt.Errorf("extract iso: %w", err)
+	}
+
+	got, err := captureSlide(tempDir, e.slideIndex)
+	if err != nil {
+		return fmt.Errorf("capture slide %d: %w", e.slideIndex, err)
+	}
+
+	if *update {
+		return writePNG(e.goldenPath, got)
+	}
+
+	goldenFile, err := os.Open(e.goldenPath)
+	if err != nil {
+		return fmt.Errorf("open golden (run with -update to create it): %w", err)
+	}
+	golden, err := png.Decode(goldenFile)
+	goldenFile.Close()
+	if err != nil {
+		return fmt.Errorf("decode golden: %w", err)
+	}
+
+	if !got.Bounds().Eq(golden.Bounds()) {
+		if err := writeDiff(e.goldenPath, got, golden, nil); err != nil {
+			return err
+		}
+		return fmt.Errorf("size mismatch: captured %v, golden %v", got.Bounds(), golden.Bounds())
+	}
+
+	var mask image.Image
+	total := got.Bounds().Dx() * got.Bounds().Dy()
+	diff, err := pixelmatch.MatchPixel(toRGBA(got), toRGBA(golden), pixelmatch.WriteTo(&mask), pixelmatch.EnableDiffMask)
+	if err != nil {
+		return fmt.Errorf("pixelmatch: %w", err)
+	}
+	if ratio := float64(diff) / float64(total); ratio > e.tolerance {
+		if err := writeDiff(e.goldenPath, got, golden, mask); err != nil {
+			return err
+		}
+		return fmt.Errorf("%d of %d pixels differ (%.4f > tolerance %.4f)", diff, total, ratio, e.tolerance)
+	}
+	return nil
+}
+
+// captureSlide extracts-serves-drives dir's Storyline course up to
+// slideIndex (1-indexed) and returns the settled screenshot, mirroring the
+// converter's own extract-serve-chromedp pipeline.
+func captureSlide(dir string, slideIndex int) (image.Image, error) {
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.WindowSize(1280, 720))
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	defer cancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	if err := chromedp.Run(ctx, chromedp.Navigate(srv.URL+"/story.html")); err != nil {
+		return nil, fmt.Errorf("navigate: %w", err)
+	}
+	time.Sleep(5 * time.Second) // let the initial slide's entrance animation settle
+
+	for i := 1; i < slideIndex; i++ {
+		var clicked bool
+		if err := chromedp.Run(ctx, chromedp.Evaluate(clickNextJS, &clicked)); err != nil {
+			return nil, fmt.Errorf("click next (slide %d): %w", i+1, err)
+		}
+		if !clicked {
+			return nil, fmt.Errorf("reached end of presentation before slide %d", slideIndex)
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	var buf []byte
+	if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
+		return nil, fmt.Errorf("capture screenshot: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot: %w", err)
+	}
+	return img, nil
+}
+
+// writeDiff writes a side-by-side comparison image (captured | golden |
+// diff mask) next to goldenPath, suffixed "-diff.png", so a mismatch can be
+// inspected without re-running the capture. mask may be nil, e.g. when the
+// two images aren't even the same size to compare pixel-by-pixel.
+func writeDiff(goldenPath string, got, golden, mask image.Image) error {
+	panels := []image.Image{got, golden}
+	if mask != nil {
+		panels = append(panels, mask)
+	}
+
+	const gap = 4
+	width, height := -gap, 0
+	for _, p := range panels {
+		width += p.Bounds().Dx() + gap
+		if h := p.Bounds().Dy(); h > height {
+			height = h
+		}
+	}
+
+	composite := image.NewRGBA(image.Rect(0, 0, width, height))
+	x := 0
+	for _, p := range panels {
+		draw.Draw(composite, image.Rect(x, 0, x+p.Bounds().Dx(), p.Bounds().Dy()), p, p.Bounds().Min, draw.Src)
+		x += p.Bounds().Dx() + gap
+	}
+
+	diffPath := strings.TrimSuffix(goldenPath, filepath.Ext(goldenPath)) + "-diff.png"
+	return writePNG(diffPath, composite)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// toRGBA returns img as *image.RGBA, converting if necessary.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, img.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
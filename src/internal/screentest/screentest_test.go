@@ -0,0 +1,219 @@
+package screentest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestGoldens runs every entry in testdata/golden.script against the sample
+// ISO corpus described in testdata/README.md. That corpus isn't checked
+// into this snapshot of the repo (Storyline exports are large), so the
+// test skips rather than failing when the script or an ISO is missing.
+func TestGoldens(t *testing.T) {
+	const script = "testdata/golden.script"
+	if _, err := os.Stat(script); err != nil {
+		t.Skipf("no golden script at %s: %v", script, err)
+	}
+
+	entries, err := parseScript(script)
+	if err != nil {
+		t.Fatalf("parseScript: %v", err)
+	}
+	for _, e := range entries {
+		if _, err := os.Stat(e.isoPath); err != nil {
+			t.Skipf("sample ISO corpus not present (%s): %v", e.isoPath, err)
+		}
+	}
+
+	if err := Check(script); err != nil {
+		t.Error(err)
+	}
+}
+
+// chromeCandidates mirrors chromedp's own search order for a headless
+// Chrome/Chromium binary (see the unexported chromedp.findExecPath),
+// trimmed to the names worth checking before skipping a browser-driven
+// test rather than failing it on environments with no browser installed.
+var chromeCandidates = []string{
+	"headless_shell", "headless-shell", "chromium", "chromium-browser",
+	"google-chrome", "google-chrome-stable", "chrome",
+}
+
+func requireChrome(t *testing.T) {
+	t.Helper()
+	for _, name := range chromeCandidates {
+		if _, err := exec.LookPath(name); err == nil {
+			return
+		}
+	}
+	t.Skip("no Chrome/Chromium binary on PATH")
+}
+
+// TestGoldensBuiltin exercises the same Check() path as TestGoldens against
+// a small synthetic fixture checked into this repo, so the golden-image
+// comparison itself (not just parseScript) is actually covered without
+// depending on a contributor-supplied Storyline export. The "course" is a
+// single-slide story.html rendering a flat background color, built
+// in-memory as a minimal ISO 9660 image, compared against the checked-in
+// golden at testdata/golden/story-slide1.png.
+func TestGoldensBuiltin(t *testing.T) {
+	requireChrome(t)
+
+	golden, err := filepath.Abs("testdata/golden/story-slide1.png")
+	if err != nil {
+		t.Fatalf("resolve golden path: %v", err)
+	}
+	if _, err := os.Stat(golden); err != nil {
+		t.Fatalf("missing checked-in golden %s: %v", golden, err)
+	}
+
+	dir := t.TempDir()
+	isoPath := filepath.Join(dir, "story.iso")
+	if err := os.WriteFile(isoPath, buildStoryISO([]byte(storyHTML)), 0644); err != nil {
+		t.Fatalf("write fixture iso: %v", err)
+	}
+
+	script := filepath.Join(dir, "golden.script")
+	contents := fmt.Sprintf("story.iso 1 %s 0.02\n", golden)
+	if err := os.WriteFile(script, []byte(contents), 0644); err != nil {
+		t.Fatalf("write fixture script: %v", err)
+	}
+
+	if err := Check(script); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "sample.script")
+	contents := "# comment\ncourse.iso 3 golden/slide3.png 0.01\n\nother.iso 1 golden/slide1.png 0\n"
+	if err := os.WriteFile(script, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := parseScript(script)
+	if err != nil {
+		t.Fatalf("parseScript: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].slideIndex != 3 || entries[0].tolerance != 0.01 {
+		t.Errorf("entries[0] = %+v, want slideIndex 3 tolerance 0.01", entries[0])
+	}
+	if got, want := entries[0].isoPath, filepath.Join(dir, "course.iso"); got != want {
+		t.Errorf("isoPath = %q, want %q", got, want)
+	}
+	if got, want := entries[1].goldenPath, filepath.Join(dir, "golden", "slide1.png"); got != want {
+		t.Errorf("goldenPath = %q, want %q", got, want)
+	}
+}
+
+// storyHTML is the single-slide fixture page compared against
+// testdata/golden/story-slide1.png: a flat, full-bleed background color
+// with no text or borders, so a headless capture of it matches the golden
+// bit-for-bit (modulo the tolerance in golden.script) with nothing for
+// font rendering or anti-aliasing to drift on between Chrome versions.
+const storyHTML = `<!doctype html>
+<html><head><style>
+html, body { margin: 0; padding: 0; width: 1280px; height: 720px; background: #2a6ef5; overflow: hidden; }
+</style></head><body></body></html>
+`
+
+// buildStoryISO hand-assembles the smallest ISO 9660 image iso.Extract can
+// walk: a Primary Volume Descriptor, a Volume Descriptor Set Terminator, a
+// root directory whose "." entry carries a Rock Ridge "SP" signature, and a
+// single file (content) named story.html via a Rock Ridge NM entry. It
+// mirrors the fixtures in internal/iso/iso_test.go, trimmed to the one file
+// this package's TestGoldensBuiltin needs.
+func buildStoryISO(content []byte) []byte {
+	const sectorSize = 2048
+	const systemArea = 16 // sectors 0-15 are reserved for the boot/system area
+	const dirFlagsDir = 1 << 1
+
+	pvdSector := systemArea
+	termSector := pvdSector + 1
+	rootSector := termSector + 1
+	fileSector := rootSector + 1
+	fileSectors := (len(content) + sectorSize - 1) / sectorSize
+	if fileSectors == 0 {
+		fileSectors = 1
+	}
+
+	buf := make([]byte, (fileSector+fileSectors)*sectorSize)
+
+	writeISODescriptorHeader(buf, pvdSector, 1)
+	writeISORootRecord(buf, pvdSector, uint32(rootSector))
+	writeISODescriptorHeader(buf, termSector, 255)
+
+	dotSystemUse := []byte{'S', 'P', 7, 1, 0xBE, 0xEF, 0}
+	var root []byte
+	root = append(root, writeISODirRecord([]byte{0x00}, uint32(rootSector), sectorSize, dirFlagsDir, dotSystemUse)...)
+	root = append(root, writeISODirRecord([]byte{0x01}, uint32(rootSector), sectorSize, dirFlagsDir, nil)...)
+	root = append(root, writeISODirRecord([]byte("STORY.HTM;1"), uint32(fileSector), uint32(len(content)), 0, isoRockRidgeNM("story.html"))...)
+	copy(buf[rootSector*sectorSize:], root)
+
+	copy(buf[fileSector*sectorSize:], content)
+
+	return buf
+}
+
+func writeISODescriptorHeader(buf []byte, sector int, descType byte) {
+	const sectorSize = 2048
+	off := sector * sectorSize
+	buf[off] = descType
+	copy(buf[off+1:off+6], "CD001")
+	buf[off+6] = 1
+}
+
+func writeISORootRecord(buf []byte, descriptorSector int, rootExtent uint32) {
+	const sectorSize = 2048
+	const dirFlagsDir = 1 << 1
+	rec := writeISODirRecord([]byte{0x00}, rootExtent, sectorSize, dirFlagsDir, nil)
+	copy(buf[descriptorSector*sectorSize+156:], rec)
+}
+
+// writeISODirRecord builds a single ISO 9660 directory record, mirroring
+// the layout the iso package's parseDirEntry reads back.
+func writeISODirRecord(id []byte, extent, size uint32, flags byte, systemUse []byte) []byte {
+	idLen := len(id)
+	pos := 33 + idLen
+	if idLen%2 == 0 {
+		pos++ // padding byte required whenever the identifier length is even
+	}
+	recLen := pos + len(systemUse)
+	if recLen%2 != 0 {
+		recLen++ // directory records always end on an even boundary
+	}
+
+	rec := make([]byte, recLen)
+	rec[0] = byte(recLen)
+	binary.LittleEndian.PutUint32(rec[2:6], extent)
+	binary.BigEndian.PutUint32(rec[6:10], extent)
+	binary.LittleEndian.PutUint32(rec[10:14], size)
+	binary.BigEndian.PutUint32(rec[14:18], size)
+	rec[25] = flags
+	binary.LittleEndian.PutUint16(rec[28:30], 1)
+	binary.BigEndian.PutUint16(rec[30:32], 1)
+	rec[32] = byte(idLen)
+	copy(rec[33:33+idLen], id)
+	copy(rec[pos:], systemUse)
+	return rec
+}
+
+// isoRockRidgeNM builds a Rock Ridge "NM" system use entry recording name
+// as an entry's real (long, mixed-case) filename.
+func isoRockRidgeNM(name string) []byte {
+	nm := make([]byte, 5+len(name))
+	copy(nm[0:2], "NM")
+	nm[2] = byte(5 + len(name))
+	nm[3] = 1
+	nm[4] = 0 // flags: not a continuation, not "." or ".."
+	copy(nm[5:], name)
+	return nm
+}
@@ -1,11 +1,10 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"image"
-	"image/jpeg"
 	_ "image/png"
 	"io"
 	"log"
@@ -16,11 +15,9 @@ import (
 	"strings"
 	"time"
 
-	"baliance.com/gooxml/common"
-	"baliance.com/gooxml/measurement"
-	"baliance.com/gooxml/presentation"
 	"github.com/chromedp/chromedp"
-	"github.com/hooklift/iso9660"
+
+	"rafaclearn/internal/iso"
 )
 
 const (
@@ -29,7 +26,26 @@ const (
 	outputPPTX = "../output.pptx"
 )
 
+var (
+	stabilityThreshold = flag.Float64("stability-threshold", 0.001, "fraction of changed pixels below which a frame is considered settled")
+	stabilityFrames    = flag.Int("stability-frames", 3, "consecutive stable frames required before a slide is captured")
+	settleTimeout      = flag.Duration("settle-timeout", 8*time.Second, "max time to wait for a slide to settle before capturing it anyway")
+	pollInterval       = flag.Duration("poll-interval", 200*time.Millisecond, "interval between frame samples while waiting for a slide to settle")
+
+	framesPerSlide = flag.Int("frames-per-slide", 1, "max number of frames to sample per slide; >1 enables animated slide capture")
+	frameInterval  = flag.Duration("frame-interval", 500*time.Millisecond, "interval between sampled frames when frames-per-slide > 1")
+	animationFmt   = flag.String("animation-format", string(animationFormatGIF), "encoding used for multi-frame slides: gif or mp4")
+
+	maxImageWidth = flag.Int("max-image-width", 0, "downscale poster images to this width in pixels; 0 disables resizing")
+	jpegQuality   = flag.Int("jpeg-quality", 90, "JPEG quality used when the poster image is encoded as JPEG")
+	imageFormat   = flag.String("image-format", "auto", "poster image encoding: auto, jpeg, png, or webp")
+
+	mode       = flag.String("mode", "batch", "capture mode: batch (fully automatic) or interactive (human-in-the-loop UI)")
+	outputFlag = flag.String("output", "pptx", "comma-separated output targets: pptx, pdf, or both")
+)
+
 func main() {
+	flag.Parse()
 	log.Println("Starting Storyline to PPTX converter...")
 
 	// 1. Find and Extract ISO
@@ -44,22 +60,7 @@ func main() {
 	}
 	defer os.RemoveAll(tempDir) // Cleanup
 
-	// 2. Start Local Server
-	port, err := getFreePort()
-	if err != nil {
-		log.Fatalf("Error getting free port: %v", err)
-	}
-	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: http.FileServer(http.Dir(tempDir))}
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("HTTP server error: %v", err)
-		}
-	}()
-	defer server.Close()
-	baseURL := fmt.Sprintf("http://localhost:%d/story.html", port)
-	log.Printf("Serving content at %s", baseURL)
-
-	// 3. Setup Chromedp
+	// 2. Setup Chromedp
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.WindowSize(1280, 720),
 	)
@@ -68,21 +69,52 @@ func main() {
 	ctx, cancel := chromedp.NewContext(allocCtx)
 	defer cancel()
 
-	// 4. Initialize PPTX
-	ppt := presentation.New()
+	// 3. Initialize output sinks (pptx, pdf, or both)
+	sinks, err := newSinks(*outputFlag)
+	if err != nil {
+		log.Fatalf("Error setting up output: %v", err)
+	}
 	defer func() {
-		f, err := os.Create(outputPPTX)
-		if err != nil {
-			log.Printf("Error creating output file: %v", err)
-			return
+		for _, sink := range sinks {
+			if err := sink.Close(); err != nil {
+				log.Printf("Error closing output sink: %v", err)
+			}
 		}
-		defer f.Close()
-		if err := ppt.Save(f); err != nil {
-			log.Printf("Error saving PPT: %v", err)
+	}()
+
+	cfg := stabilityConfig{
+		threshold:    *stabilityThreshold,
+		settleFrames: *stabilityFrames,
+		pollInterval: *pollInterval,
+		timeout:      *settleTimeout,
+	}
+
+	// 4. Start Local Server. In interactive mode the operator's UI is served
+	// alongside the Storyline content on the same port; batch mode serves
+	// just the content.
+	mux := http.NewServeMux()
+	var ic *interactiveController
+	if *mode == "interactive" {
+		ic = newInteractiveController(ctx, sinks, cfg)
+		ic.registerRoutes(mux)
+	}
+	mux.Handle("/", http.FileServer(http.Dir(tempDir)))
+
+	port, err := getFreePort()
+	if err != nil {
+		log.Fatalf("Error getting free port: %v", err)
+	}
+	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server error: %v", err)
 		}
 	}()
+	defer server.Close()
+	baseURL := fmt.Sprintf("http://localhost:%d/story.html", port)
+	log.Printf("Serving content at %s", baseURL)
 
-	// 5. Scrape Loop
+	// 5. Navigate and settle the initial page
 	log.Println("Navigating to story...")
 	if err := chromedp.Run(ctx, chromedp.Navigate(baseURL)); err != nil {
 		log.Fatalf("Error navigating: %v", err)
@@ -101,53 +133,57 @@ func main() {
 		log.Printf("Warning: Could not hide controls: %v", err)
 	}
 
-	slideIndex := 1
-	for {
-		log.Printf("Processing Slide %d...", slideIndex)
+	// 6. Hand off to the selected mode
+	if *mode == "interactive" {
+		log.Printf("Interactive capture UI ready at http://localhost:%d/ui", port)
+		ic.wait()
+	} else {
+		runBatch(ctx, sinks, cfg)
+	}
 
-		// Wait for slide content to settle
-		time.Sleep(2 * time.Second)
+	log.Printf("Saved output (%s) from %s", *outputFlag, outputPPTX)
+}
 
-		// Capture Screenshot
-		var buf []byte
-		if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&buf)); err != nil {
-			log.Printf("Error capturing screenshot: %v", err)
-			break
-		}
+// runBatch drives the fully automatic headless capture loop: settle each
+// slide, fan it out to every configured sink, click Next, and repeat until
+// Next is a no-op.
+func runBatch(ctx context.Context, sinks []SlideSink, cfg stabilityConfig) {
+	seq, err := captureFrameSequence(ctx, cfg, *framesPerSlide, *frameInterval)
+	if err != nil {
+		log.Fatalf("Error capturing first slide: %v", err)
+	}
+
+	slideIndex := 1
+	for {
+		log.Printf("Processing Slide %d (%d frame(s) captured, poster settled with %d differing pixels)...",
+			slideIndex, len(seq.frames), seq.poster.diffCount)
 
 		// Extract Text (for editable notes)
 		var slideText string
-		extractTextJS := `document.body.innerText`
 		if err := chromedp.Run(ctx, chromedp.Evaluate(extractTextJS, &slideText)); err != nil {
 			log.Printf("Warning: Could not extract text: %v", err)
 		}
 
-		// Add to PPTX
-		if err := addSlideToPPT(ppt, buf, slideText); err != nil {
-			log.Printf("Error adding slide to PPT: %v", err)
+		// Fan the slide out to every configured output
+		if err := addSlideToSinks(sinks, seq, slideText); err != nil {
+			log.Printf("Error adding slide: %v", err)
 		}
 
-		// Check for "Next" button and click
-		var nextDisabled bool
-		// Common Storyline Next Button Selectors
-		checkNextJS := `
-			(function() {
-				const btn = document.querySelector('#next') || 
-							document.querySelector('.next-button') || 
-							document.querySelector('div[data-model-id="5hW..."]'); // Generic fallback
-				if (!btn) return true; // No button found, maybe end
-				if (btn.classList.contains('disabled') || btn.getAttribute('aria-disabled') === 'true') return true;
-				btn.click();
-				return false;
-			})()
-		`
-		if err := chromedp.Run(ctx, chromedp.Evaluate(checkNextJS, &nextDisabled)); err != nil {
-			log.Printf("Error checking next button: %v", err)
+		_, noop, err := clickNextAndWait(ctx, cfg, seq.poster)
+		if err != nil {
+			log.Printf("Error advancing to next slide: %v", err)
+			break
+		}
+		if noop {
+			log.Println("End of presentation reached (Next click was a no-op).")
 			break
 		}
 
-		if nextDisabled {
-			log.Println("End of presentation reached.")
+		// Re-sample from scratch so the new slide's own animation (if any)
+		// is captured, rather than reusing the single settled frame above.
+		seq, err = captureFrameSequence(ctx, cfg, *framesPerSlide, *frameInterval)
+		if err != nil {
+			log.Printf("Error capturing slide %d: %v", slideIndex+1, err)
 			break
 		}
 
@@ -157,92 +193,84 @@ func main() {
 			break
 		}
 	}
-
-	log.Printf("Saved PowerPoint to %s", outputPPTX)
 }
 
-// addSlideToPPT adds a screenshot and notes to a new slide
-func addSlideToPPT(ppt *presentation.Presentation, imgBytes []byte, notes string) error {
-	slide := ppt.AddSlide()
-
-	// Add Image
-	// Decode PNG from memory to ensure validity and convert to JPEG
-	// Converting to JPEG avoids potential PNG decoding issues in gooxml v1.0.1
-	srcImg, _, err := image.Decode(bytes.NewReader(imgBytes))
-	if err != nil {
-		return fmt.Errorf("failed to decode screenshot: %w", err)
-	}
-
-	// Write image to temp file
-	tmpFile, err := os.CreateTemp(tempDir, "slide-*.jpg")
+// addSlideToSinks prepares the final encoded image for seq and hands it,
+// along with notes, to every configured SlideSink.
+func addSlideToSinks(sinks []SlideSink, seq *frameSequence, notes string) error {
+	img, err := prepareSlideImage(seq)
 	if err != nil {
 		return err
 	}
-
-	if err := jpeg.Encode(tmpFile, srcImg, &jpeg.Options{Quality: 90}); err != nil {
-		tmpFile.Close()
-		return err
-	}
-	tmpFile.Sync() // Ensure data is flushed to disk
-	if err := tmpFile.Close(); err != nil {
-		return err
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.AddSlide(img, notes); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	absPath, err := filepath.Abs(tmpFile.Name())
-	if err != nil {
-		return err
+// prepareSlideImage encodes seq down to the bytes a SlideSink should embed.
+// When seq holds more than one frame and multi-frame capture is enabled,
+// the animation is encoded per --animation-format: a GIF is used directly
+// as the slide image, while an MP4 is saved alongside the deck and logged,
+// since neither gooxml nor the PDF sink can embed video, and the poster
+// frame is used instead. Note that even the GIF path only animates when the
+// output is viewed outside PowerPoint (a browser, an image viewer): PPTX
+// embeds a picture as a static first frame, not an auto-playing media
+// object, so slides in the deck itself show the GIF's first frame only.
+func prepareSlideImage(seq *frameSequence) ([]byte, error) {
+	if *framesPerSlide > 1 && len(seq.frames) > 1 {
+		animPath, err := encodeAnimation(seq.frames, *frameInterval, animationFormat(*animationFmt), tempDir)
+		if err != nil {
+			log.Printf("Warning: could not encode slide animation, falling back to static poster: %v", err)
+		} else if animationFormat(*animationFmt) == animationFormatGIF {
+			data, err := os.ReadFile(animPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read encoded animation: %w", err)
+			}
+			return data, nil
+		} else {
+			log.Printf("Saved animation sidecar %s (cannot be embedded; slide uses the static poster frame)", animPath)
+		}
 	}
 
-	// Verify image is valid before passing to gooxml
-	// This helps diagnose "image must have a valid size" errors
-	f, err := os.Open(absPath)
+	pi, err := processImage(seq.poster.img, *maxImageWidth, *jpegQuality, *imageFormat)
 	if err != nil {
-		return fmt.Errorf("unable to open image for verification: %w", err)
+		return nil, fmt.Errorf("failed to process poster image: %w", err)
 	}
-	fi, err := f.Stat()
-	if err != nil {
-		f.Close()
-		return fmt.Errorf("unable to stat image: %w", err)
+	return pi.bytes, nil
+}
+
+// writeImageTemp writes already-encoded image data to a new temp file under
+// tempDir, named for its format, and returns its absolute path. The bytes
+// are written once since the pipeline keeps them in memory until now.
+func writeImageTemp(data []byte, format string) (string, error) {
+	ext := ".jpg"
+	switch format {
+	case "png":
+		ext = ".png"
+	case "webp":
+		ext = ".webp"
 	}
-	var cfg image.Config
-	cfg, _, err = image.DecodeConfig(f)
-	f.Close()
+
+	tmpFile, err := os.CreateTemp(tempDir, "slide-*"+ext)
 	if err != nil {
-		return fmt.Errorf("invalid image data: %w", err)
+		return "", err
 	}
-	log.Printf("Debug: Image verified at %s (Size: %d bytes, Dim: %dx%d)", absPath, fi.Size(), cfg.Width, cfg.Height)
-
-	img := common.Image{
-		Path:   absPath,
-		Format: "jpeg",
+	path, err := filepath.Abs(tmpFile.Name())
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
 	}
-	imgRef, err := ppt.AddImage(img)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Create an image box filling the slide (assuming 16:9 aspect ratio roughly)
-	imgBox := slide.AddImage(imgRef)
-	imgBox.Properties().SetPosition(0, 0)
-	// Standard PPT size is often 16x9 inches or similar, gooxml defaults might vary.
-	// We set it to cover a standard wide slide.
-	imgBox.Properties().SetSize(measurement.Distance(13.33*measurement.Inch), measurement.Distance(7.5*measurement.Inch))
-
-	// Add Notes (Editable Text)
-	// Note: gooxml support for notes is limited in older versions,
-	// so we might just print it to console or try to add a hidden text box if notes fail.
-	// For this example, we will add a text box at the bottom (off-screen or visible) containing the text.
-
-	// Adding a text box with the extracted text for maintainability
-	tb := slide.AddTextBox()
-	tb.Properties().SetPosition(measurement.Distance(0.5*measurement.Inch), measurement.Distance(7.6*measurement.Inch))
-	tb.Properties().SetSize(measurement.Distance(12*measurement.Inch), measurement.Distance(2*measurement.Inch))
-	p := tb.AddParagraph()
-	run := p.AddRun()
-	run.SetText("Extracted Text: " + strings.ReplaceAll(notes, "\n", " "))
-	run.Properties().SetSize(10 * measurement.Point)
-
-	return nil
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
 // findISO looks for the first .iso file in the directory
@@ -267,57 +295,11 @@ func findISO(dir string) (string, error) {
 	return isoPath, err
 }
 
-// extractISO extracts the ISO content to dest
+// extractISO extracts the ISO content to dest, preferring Rock Ridge or
+// Joliet filenames over plain ISO 9660's truncated 8.3 names when the disc
+// carries either extension (see internal/iso).
 func extractISO(isoPath, dest string) error {
-	f, err := os.Open(isoPath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	r, err := iso9660.NewReader(f)
-	if err != nil {
-		return fmt.Errorf("failed to open ISO reader: %w", err)
-	}
-
-	for {
-		f, err := r.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		// Construct target path
-		// Note: iso9660 paths are usually / separated and uppercase
-		relPath := strings.TrimLeft(f.Name(), "/")
-		targetPath := filepath.Join(dest, relPath)
-
-		if f.IsDir() {
-			if err := os.MkdirAll(targetPath, 0755); err != nil {
-				return err
-			}
-			continue
-		}
-
-		// Ensure parent dir exists
-		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-			return err
-		}
-
-		// Write file
-		outFile, err := os.Create(targetPath)
-		if err != nil {
-			return err
-		}
-		if _, err := io.Copy(outFile, f.Sys().(io.Reader)); err != nil {
-			outFile.Close()
-			return err
-		}
-		outFile.Close()
-	}
-	return nil
+	return iso.Extract(isoPath, dest)
 }
 
 // getFreePort asks the kernel for a free open port
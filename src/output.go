@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"log"
+	"os"
+	"strings"
+
+	"baliance.com/gooxml/common"
+	"baliance.com/gooxml/measurement"
+	"baliance.com/gooxml/presentation"
+	"github.com/jung-kurt/gofpdf"
+	_ "golang.org/x/image/webp"
+)
+
+const outputPDF = "../output.pdf"
+
+// SlideSink receives one already-encoded slide image (JPEG, PNG, or GIF)
+// plus its extracted notes text and turns that into a page of its own
+// output format. The capture loop fans the same slide out to every
+// configured sink, so a single run can produce several output formats.
+type SlideSink interface {
+	AddSlide(img []byte, text string) error
+	Close() error
+}
+
+// newSinks builds one SlideSink per comma-separated target in outputFlag
+// ("pptx", "pdf", or both).
+func newSinks(outputFlag string) ([]SlideSink, error) {
+	var sinks []SlideSink
+	for _, target := range strings.Split(outputFlag, ",") {
+		switch strings.TrimSpace(target) {
+		case "pptx":
+			sinks = append(sinks, newPPTXSink(outputPPTX))
+		case "pdf":
+			sinks = append(sinks, newPDFSink(outputPDF))
+		case "":
+			// ignore stray commas/whitespace
+		default:
+			return nil, fmt.Errorf("unknown --output target %q (want pptx, pdf)", target)
+		}
+	}
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("--output produced no sinks from %q", outputFlag)
+	}
+	return sinks, nil
+}
+
+// sniffImage decodes just the header of img to recover its format ("jpeg",
+// "png", or "gif") and dimensions.
+func sniffImage(img []byte) (format string, cfg image.Config, err error) {
+	cfg, format, err = image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return "", image.Config{}, fmt.Errorf("invalid image data: %w", err)
+	}
+	return format, cfg, nil
+}
+
+// pptxSink writes slides into a gooxml presentation and saves it to path on
+// Close. This is the original output format the converter supported.
+type pptxSink struct {
+	ppt  *presentation.Presentation
+	path string
+}
+
+func newPPTXSink(path string) *pptxSink {
+	return &pptxSink{ppt: presentation.New(), path: path}
+}
+
+func (s *pptxSink) AddSlide(img []byte, text string) error {
+	format, cfg, err := sniffImage(img)
+	if err != nil {
+		return err
+	}
+
+	// Write image to temp file once; gooxml's AddImage needs a path, not bytes.
+	imgPath, err := writeImageTemp(img, format)
+	if err != nil {
+		return fmt.Errorf("failed to write slide image: %w", err)
+	}
+	log.Printf("Debug: Image verified at %s (Format: %s, Dim: %dx%d)", imgPath, format, cfg.Width, cfg.Height)
+
+	slide := s.ppt.AddSlide()
+	imgRef, err := s.ppt.AddImage(common.Image{
+		Path:   imgPath,
+		Format: format,
+		Size:   image.Point{X: cfg.Width, Y: cfg.Height},
+	})
+	if err != nil {
+		return err
+	}
+	// presentation.AddImage (unlike document.AddImage) never registers a
+	// content type for anything beyond gooxml's built-in png/jpeg/jpg/wmf
+	// defaults, so gif and webp slides would otherwise ship with no
+	// Default entry for their media part in [Content_Types].xml.
+	if format != "png" && format != "jpeg" && format != "jpg" && format != "wmf" {
+		s.ppt.ContentTypes.EnsureDefault(format, "image/"+format)
+	}
+
+	// Create an image box filling the slide (assuming 16:9 aspect ratio roughly)
+	imgBox := slide.AddImage(imgRef)
+	imgBox.Properties().SetPosition(0, 0)
+	imgBox.Properties().SetSize(measurement.Distance(13.33*measurement.Inch), measurement.Distance(7.5*measurement.Inch))
+
+	// Adding a text box with the extracted text for maintainability
+	tb := slide.AddTextBox()
+	tb.Properties().SetPosition(measurement.Distance(0.5*measurement.Inch), measurement.Distance(7.6*measurement.Inch))
+	tb.Properties().SetSize(measurement.Distance(12*measurement.Inch), measurement.Distance(2*measurement.Inch))
+	p := tb.AddParagraph()
+	run := p.AddRun()
+	run.SetText("Extracted Text: " + strings.ReplaceAll(text, "\n", " "))
+	run.Properties().SetSize(10 * measurement.Point)
+
+	return nil
+}
+
+func (s *pptxSink) Close() error {
+	f, err := os.Create(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.ppt.Save(f)
+}
+
+// pdfSink writes one PDF page per slide: the slide image at page size plus
+// an invisible text layer of the extracted notes at the bottom margin, so
+// the deck stays copy/paste- and grep-searchable even though a PDF isn't
+// editable the way a PPTX is.
+type pdfSink struct {
+	pdf  *gofpdf.Fpdf
+	path string
+	n    int
+}
+
+func newPDFSink(path string) *pdfSink {
+	// Match the pptx sink's 16:9 page (13.33x7.5in) rather than "Letter"
+	// (1.29:1), so a 1280x720 screenshot isn't stretched off its aspect ratio.
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: "L",
+		UnitStr:        "in",
+		Size:           gofpdf.SizeType{Wd: 13.33, Ht: 7.5},
+	})
+	pdf.SetMargins(0, 0, 0)
+	return &pdfSink{pdf: pdf, path: path}
+}
+
+func (s *pdfSink) AddSlide(img []byte, text string) error {
+	format, _, err := sniffImage(img)
+	if err != nil {
+		return err
+	}
+	gofpdfType, ok := map[string]string{"jpeg": "JPG", "png": "PNG", "gif": "GIF"}[format]
+	if !ok {
+		return fmt.Errorf("pdf sink: unsupported image format %q", format)
+	}
+
+	s.pdf.AddPage()
+	s.n++
+	imgName := fmt.Sprintf("slide-%d", s.n)
+	opts := gofpdf.ImageOptions{ImageType: gofpdfType}
+	s.pdf.RegisterImageOptionsReader(imgName, opts, bytes.NewReader(img))
+
+	w, h := s.pdf.GetPageSize()
+	s.pdf.ImageOptions(imgName, 0, 0, w, h, false, opts, 0, "")
+
+	// Render the notes at zero opacity: invisible to the eye, but still
+	// real text in the page content stream, so it's selectable and
+	// searchable like a scanned-document OCR layer.
+	s.pdf.SetFont("Arial", "", 8)
+	s.pdf.SetAlpha(0, "Normal")
+	s.pdf.SetXY(0.25, h-0.75)
+	s.pdf.MultiCell(w-0.5, 0.15, strings.ReplaceAll(text, "\r\n", " "), "", "", false)
+	s.pdf.SetAlpha(1, "Normal")
+
+	return s.pdf.Error()
+}
+
+func (s *pdfSink) Close() error {
+	return s.pdf.OutputFileAndClose(s.path)
+}